@@ -0,0 +1,139 @@
+package rowlock
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// evictableEntry is the value stored per row in EvictableRowLock's map.
+//
+// refs counts the number of goroutines currently holding or waiting on
+// locker; an entry is created with refs at one, for the goroutine that
+// created it. The Unlock that drops refs to zero removes the entry from the
+// map, and once refs is zero it is never incremented again (see
+// tryAcquireRef).
+type evictableEntry struct {
+	locker sync.Locker
+	refs   int32
+}
+
+// EvictableRowLock defines a set of locks whose entries are removed once
+// idle.
+//
+// Unlike RowLock, which keeps a locker for every distinct row it has ever
+// seen, EvictableRowLock removes a row's entry (and returns its locker to
+// the pool) as soon as the row has no active holders, bounding memory for
+// workloads with high-cardinality, rarely-repeated keys.
+type EvictableRowLock struct {
+	locks      sync.Map
+	lockerPool sync.Pool
+	opts       Options
+}
+
+// NewEvictableRowLock creates a new EvictableRowLock with the given
+// NewLocker.
+func NewEvictableRowLock(f NewLocker) *EvictableRowLock {
+	return NewEvictableRowLockWithOptions(f, Options{})
+}
+
+// NewEvictableRowLockWithOptions creates a new EvictableRowLock with the
+// given NewLocker, instrumented with the given Options.
+func NewEvictableRowLockWithOptions(f NewLocker, opts Options) *EvictableRowLock {
+	return &EvictableRowLock{
+		lockerPool: sync.Pool{
+			New: func() interface{} {
+				return f()
+			},
+		},
+		opts: opts,
+	}
+}
+
+// Lock locks a row.
+//
+// If this is a new row, or the row's entry was evicted, a new entry is
+// created with a locker taken from the pool, which creates one using the
+// NewLocker specified in NewEvictableRowLock only when it has none to reuse.
+func (rl *EvictableRowLock) Lock(row Row) {
+	if rl.opts.OnWaitStart != nil {
+		rl.opts.OnWaitStart(row)
+	}
+	start := time.Now()
+	rl.getEntry(row).locker.Lock()
+	if rl.opts.OnLock != nil {
+		rl.opts.OnLock(row, time.Since(start))
+	}
+}
+
+// Unlock unlocks a row.
+//
+// If no other goroutine is holding or waiting on the row's lock, its entry
+// is removed and the locker returned to the pool.
+func (rl *EvictableRowLock) Unlock(row Row) {
+	v, ok := rl.locks.Load(row)
+	if !ok {
+		panic("rowlock: Unlock of unlocked row")
+	}
+	e := v.(*evictableEntry)
+	e.locker.Unlock()
+	if rl.opts.OnUnlock != nil {
+		rl.opts.OnUnlock(row)
+	}
+	if atomic.AddInt32(&e.refs, -1) == 0 {
+		rl.locks.CompareAndDelete(row, v)
+		rl.lockerPool.Put(e.locker)
+		if rl.opts.OnLockerEvicted != nil {
+			rl.opts.OnLockerEvicted(row)
+		}
+	}
+}
+
+// getEntry returns the entry for the given row, creating one if needed, with
+// refs already incremented to account for the caller.
+//
+// If this is a new row, the new entry's locker is taken from the pool, which
+// creates one using the NewLocker specified in NewEvictableRowLock only when
+// it has none to reuse.
+func (rl *EvictableRowLock) getEntry(row Row) *evictableEntry {
+	for {
+		if v, ok := rl.locks.Load(row); ok {
+			e := v.(*evictableEntry)
+			if rl.tryAcquireRef(e) {
+				return e
+			}
+			continue // e is being evicted concurrently; retry.
+		}
+
+		newLocker := rl.lockerPool.Get()
+		e := &evictableEntry{locker: newLocker.(sync.Locker), refs: 1}
+		actual, loaded := rl.locks.LoadOrStore(row, e)
+		if !loaded {
+			if rl.opts.OnLockerCreated != nil {
+				rl.opts.OnLockerCreated(row)
+			}
+			return e
+		}
+		rl.lockerPool.Put(newLocker)
+
+		if ae := actual.(*evictableEntry); rl.tryAcquireRef(ae) {
+			return ae
+		}
+		// ae is being evicted concurrently; retry.
+	}
+}
+
+// tryAcquireRef increments e.refs, unless it has already reached zero, in
+// which case e is being evicted and the caller must retry against a fresh
+// entry.
+func (rl *EvictableRowLock) tryAcquireRef(e *evictableEntry) bool {
+	for {
+		refs := atomic.LoadInt32(&e.refs)
+		if refs == 0 {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&e.refs, refs, refs+1) {
+			return true
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package rowlock
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestEvictableRowLockMutualExclusion(t *testing.T) {
+	rl := NewEvictableRowLock(MutexNewLocker)
+
+	var counter int
+	var wg sync.WaitGroup
+	const goroutines = 16
+	const iterations = 200
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				rl.Lock("row")
+				counter++
+				rl.Unlock("row")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counter != goroutines*iterations {
+		t.Fatalf("counter = %d, want %d (lost updates indicate a broken lock)", counter, goroutines*iterations)
+	}
+}
+
+func TestEvictableRowLockEvictsIdleRow(t *testing.T) {
+	rl := NewEvictableRowLock(MutexNewLocker)
+
+	rl.Lock("row")
+	rl.Unlock("row")
+
+	if _, ok := rl.locks.Load("row"); ok {
+		t.Fatal("row entry was not evicted after its last holder unlocked")
+	}
+}
+
+// TestEvictableRowLockEvictionRaceAcrossRows hammers a small set of rows from
+// many goroutines so that Lock racing Unlock's eviction (getEntry's retry
+// loop against a row mid-CompareAndDelete) is exercised repeatedly; run with
+// -race.
+func TestEvictableRowLockEvictionRaceAcrossRows(t *testing.T) {
+	rl := NewEvictableRowLock(MutexNewLocker)
+
+	rows := []string{"a", "b", "c", "d"}
+	var wg sync.WaitGroup
+	const goroutines = 32
+	const iterations = 500
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			row := rows[i%len(rows)]
+			for j := 0; j < iterations; j++ {
+				rl.Lock(row)
+				rl.Unlock(row)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	rl.locks.Range(func(key, _ interface{}) bool {
+		t.Errorf("row %v still has an entry after all holders released it", key)
+		return true
+	})
+}
+
+func TestEvictableRowLockOptionsHooksFireOnEviction(t *testing.T) {
+	var created, evicted int32
+	rl := NewEvictableRowLockWithOptions(MutexNewLocker, Options{
+		OnLockerCreated: func(row Row) { atomic.AddInt32(&created, 1) },
+		OnLockerEvicted: func(row Row) { atomic.AddInt32(&evicted, 1) },
+	})
+
+	rl.Lock("row")
+	rl.Unlock("row")
+	rl.Lock("row")
+	rl.Unlock("row")
+
+	if created != evicted {
+		t.Fatalf("created = %d, evicted = %d; every created locker should eventually be evicted once idle", created, evicted)
+	}
+	if created < 2 {
+		t.Fatalf("created = %d, want at least 2 (each Lock after full eviction should recreate the entry)", created)
+	}
+}
+
+// TestEvictableRowLockReusesEvictedLockers checks that an evicted row's
+// locker is actually returned to lockerPool instead of being discarded, by
+// counting how many times NewLocker is invoked across many sequential
+// lock/evict/re-lock cycles on the same row.
+func TestEvictableRowLockReusesEvictedLockers(t *testing.T) {
+	var allocs int32
+	rl := NewEvictableRowLock(func() sync.Locker {
+		atomic.AddInt32(&allocs, 1)
+		return new(sync.Mutex)
+	})
+
+	const iterations = 100
+	for i := 0; i < iterations; i++ {
+		rl.Lock("row")
+		rl.Unlock("row")
+	}
+
+	if allocs >= iterations {
+		t.Fatalf("NewLocker called %d times across %d lock/evict cycles on the same row; evicted lockers are not being reused from lockerPool", allocs, iterations)
+	}
+}
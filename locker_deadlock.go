@@ -0,0 +1,21 @@
+//go:build deadlock
+
+package rowlock
+
+import (
+	"sync"
+
+	"github.com/sasha-s/go-deadlock"
+)
+
+// newDefaultLocker creates the locker backing DefaultNewLocker.
+//
+// This is the "deadlock" build, selected with -tags deadlock. It returns a
+// deadlock.Mutex, which logs a stack trace (instead of deadlocking silently)
+// when a lock is held longer than deadlock.Opts.DeadlockTimeout or a
+// lock-ordering cycle across rows is detected. Per-row locking is
+// particularly prone to such cycles when goroutines take two rows in
+// inconsistent order, which is what makes this build worth having.
+func newDefaultLocker() sync.Locker {
+	return new(deadlock.Mutex)
+}
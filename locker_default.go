@@ -0,0 +1,13 @@
+//go:build !deadlock
+
+package rowlock
+
+import "sync"
+
+// newDefaultLocker creates the locker backing DefaultNewLocker.
+//
+// This is the plain build; it returns a sync.Mutex. Build with
+// -tags deadlock to swap this for a locker that detects deadlocks.
+func newDefaultLocker() sync.Locker {
+	return new(sync.Mutex)
+}
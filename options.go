@@ -0,0 +1,50 @@
+package rowlock
+
+import (
+	"expvar"
+	"fmt"
+	"time"
+)
+
+// Options configures instrumentation hooks for row lockers.
+//
+// Each hook may be left nil, in which case it is skipped. Hooks are called
+// synchronously on the calling goroutine, so they should be cheap and
+// non-blocking; bridge them to a metrics or tracing system of choice (e.g.
+// Prometheus or OpenTelemetry) in user code.
+type Options struct {
+	// OnWaitStart is called just before a goroutine starts waiting to
+	// acquire row's lock.
+	OnWaitStart func(row Row)
+
+	// OnLock is called once a goroutine has acquired row's lock. wait is how
+	// long it waited, measured from when OnWaitStart returned; time spent in
+	// OnWaitStart is not included.
+	OnLock func(row Row, wait time.Duration)
+
+	// OnUnlock is called when row's lock is released.
+	OnUnlock func(row Row)
+
+	// OnLockerCreated is called when a locker is stored for a row that has no
+	// entry: a previously-unseen row or, in EvictableRowLock, a row whose
+	// entry was evicted.
+	OnLockerCreated func(row Row)
+
+	// OnLockerEvicted is called when a row's locker is removed because it
+	// has no active holders. Only EvictableRowLock evicts lockers; RowLock
+	// and ShardedRowLock never call this hook.
+	OnLockerEvicted func(row Row)
+}
+
+// ExpvarOptions returns Options that publish, under m, the number of times
+// each row has been locked and the cumulative time goroutines have spent
+// waiting for it. Rows are keyed by fmt.Sprint(row).
+func ExpvarOptions(m *expvar.Map) Options {
+	return Options{
+		OnLock: func(row Row, wait time.Duration) {
+			key := fmt.Sprint(row)
+			m.Add(key+".locks", 1)
+			m.Add(key+".wait_ns", wait.Nanoseconds())
+		},
+	}
+}
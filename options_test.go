@@ -0,0 +1,77 @@
+package rowlock
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRowLockTryLockFiresOnLockHook(t *testing.T) {
+	var locks, waitStarts int32
+	rl := NewRowLockWithOptions(MutexNewLocker, Options{
+		OnWaitStart: func(row Row) { atomic.AddInt32(&waitStarts, 1) },
+		OnLock:      func(row Row, _ time.Duration) { atomic.AddInt32(&locks, 1) },
+	})
+
+	if !rl.TryLock("row") {
+		t.Fatal("TryLock failed on an uncontended row")
+	}
+	rl.Unlock("row")
+
+	if waitStarts != 1 {
+		t.Fatalf("OnWaitStart fired %d times via TryLock, want 1", waitStarts)
+	}
+	if locks != 1 {
+		t.Fatalf("OnLock fired %d times via TryLock, want 1", locks)
+	}
+
+	// A failed TryLock should not report an acquisition.
+	rl.Lock("row2") // fires OnLock once on its own, bringing the total to 2.
+	before := atomic.LoadInt32(&locks)
+	if rl.TryLock("row2") {
+		t.Fatal("TryLock succeeded on a row already held elsewhere")
+	}
+	if after := atomic.LoadInt32(&locks); after != before {
+		t.Fatalf("OnLock fired after a failed TryLock: before=%d after=%d", before, after)
+	}
+	rl.Unlock("row2")
+}
+
+// TestRowLockContextFiresHooksOncePerCall verifies that LockContext, which
+// polls TryLock internally under contention, fires OnWaitStart/OnLock
+// exactly once for the whole call rather than once per poll, and that the
+// reported wait duration covers the full contended wait.
+func TestRowLockContextFiresHooksOncePerCall(t *testing.T) {
+	var waitStarts int32
+	var reportedWait time.Duration
+	rl := NewRowLockWithOptions(MutexNewLocker, Options{
+		OnWaitStart: func(row Row) { atomic.AddInt32(&waitStarts, 1) },
+		OnLock:      func(row Row, wait time.Duration) { reportedWait = wait },
+	})
+
+	rl.Lock("row")
+	atomic.StoreInt32(&waitStarts, 0) // ignore the OnWaitStart fired by the Lock above.
+	const holdTime = 100 * time.Millisecond
+	unlocked := make(chan struct{})
+	go func() {
+		time.Sleep(holdTime)
+		rl.Unlock("row")
+		close(unlocked)
+	}()
+
+	start := time.Now()
+	if err := rl.LockContext(context.Background(), "row"); err != nil {
+		t.Fatalf("LockContext returned error: %v", err)
+	}
+	elapsed := time.Since(start)
+	<-unlocked
+	rl.Unlock("row")
+
+	if waitStarts != 1 {
+		t.Fatalf("OnWaitStart fired %d times during one LockContext call, want 1", waitStarts)
+	}
+	if reportedWait < holdTime/2 {
+		t.Fatalf("OnLock reported wait=%v, want roughly the full contended wait (~%v, actual call took %v)", reportedWait, holdTime, elapsed)
+	}
+}
@@ -0,0 +1,53 @@
+package rowlock
+
+import (
+	"sync"
+)
+
+// RowCond defines a set of condition variables, one per row, analogous to
+// sync.Cond.
+//
+// Each row's condition variable is bound to that row's locker in the
+// underlying RowLock, so Wait atomically releases and re-acquires the same
+// lock that Lock/Unlock on that row use.
+type RowCond struct {
+	rl    *RowLock
+	conds sync.Map
+}
+
+// NewRowCond creates a new RowCond backed by rl.
+//
+// Callers must hold the row's lock (via rl.Lock) before calling Wait for that
+// row. As with sync.Cond, holding it while calling Signal or Broadcast is
+// allowed but not required.
+func NewRowCond(rl *RowLock) *RowCond {
+	return &RowCond{rl: rl}
+}
+
+// Wait atomically unlocks row's locker and suspends execution of the calling
+// goroutine. After later resuming execution, Wait locks row's locker before
+// returning. See sync.Cond.Wait for the usage pattern and its caveats.
+func (rc *RowCond) Wait(row Row) {
+	rc.getCond(row).Wait()
+}
+
+// Signal wakes one goroutine waiting on row, if there is any.
+func (rc *RowCond) Signal(row Row) {
+	rc.getCond(row).Signal()
+}
+
+// Broadcast wakes all goroutines waiting on row.
+func (rc *RowCond) Broadcast(row Row) {
+	rc.getCond(row).Broadcast()
+}
+
+// getCond returns the condition variable for the given row, creating one
+// bound to the row's RowLock locker if this is a new row.
+func (rc *RowCond) getCond(row Row) *sync.Cond {
+	if c, ok := rc.conds.Load(row); ok {
+		return c.(*sync.Cond)
+	}
+	c := sync.NewCond(rc.rl.getLocker(row))
+	actual, _ := rc.conds.LoadOrStore(row, c)
+	return actual.(*sync.Cond)
+}
@@ -1,7 +1,9 @@
 package rowlock
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 // NewLocker defines a type of function that can be used to create a new Locker.
@@ -17,6 +19,12 @@ func MutexNewLocker() sync.Locker {
 	return new(sync.Mutex)
 }
 
+// DefaultNewLocker is the NewLocker used by callers that don't need a
+// specific locker implementation. It behaves like MutexNewLocker, unless the
+// binary is built with the "deadlock" build tag, in which case it creates a
+// deadlock-detecting locker instead; see newDefaultLocker.
+var DefaultNewLocker NewLocker = newDefaultLocker
+
 // RowLock defines a set of locks.
 //
 // When you do Lock/Unlock operations, you don't do them on a global scale.
@@ -24,16 +32,28 @@ func MutexNewLocker() sync.Locker {
 type RowLock struct {
 	locks      sync.Map
 	lockerPool sync.Pool
+	opts       Options
 }
 
 // NewRowLock creates a new RowLock with the given NewLocker.
 func NewRowLock(f NewLocker) *RowLock {
+	return NewRowLockWithOptions(f, Options{})
+}
+
+// NewRowLockWithOptions creates a new RowLock with the given NewLocker,
+// instrumented with the given Options.
+func NewRowLockWithOptions(f NewLocker, opts Options) *RowLock {
 	return &RowLock{
 		lockerPool: sync.Pool{
 			New: func() interface{} {
-				return f()
+				l := f()
+				if _, ok := l.(TryLocker); ok {
+					return l
+				}
+				return newChanTryLocker(l)
 			},
 		},
+		opts: opts,
 	}
 }
 
@@ -42,12 +62,71 @@ func NewRowLock(f NewLocker) *RowLock {
 // If this is a new row,
 // a new locker will be created using the NewLocker specified in NewRowLock.
 func (rl *RowLock) Lock(row Row) {
+	if rl.opts.OnWaitStart != nil {
+		rl.opts.OnWaitStart(row)
+	}
+	start := time.Now()
 	rl.getLocker(row).Lock()
+	if rl.opts.OnLock != nil {
+		rl.opts.OnLock(row, time.Since(start))
+	}
 }
 
 // Unlock unlocks a row.
 func (rl *RowLock) Unlock(row Row) {
 	rl.getLocker(row).Unlock()
+	if rl.opts.OnUnlock != nil {
+		rl.opts.OnUnlock(row)
+	}
+}
+
+// TryLock tries to lock a row without blocking.
+//
+// It reports whether the lock was acquired. OnWaitStart fires before the
+// attempt, whether or not it succeeds; OnLock fires only on success, timed
+// around this single attempt.
+//
+// If this is a new row,
+// a new locker will be created using the NewLocker specified in NewRowLock.
+func (rl *RowLock) TryLock(row Row) bool {
+	if rl.opts.OnWaitStart != nil {
+		rl.opts.OnWaitStart(row)
+	}
+	start := time.Now()
+	if !rl.tryLock(row) {
+		return false
+	}
+	if rl.opts.OnLock != nil {
+		rl.opts.OnLock(row, time.Since(start))
+	}
+	return true
+}
+
+// tryLock is TryLock without the OnWaitStart/OnLock hooks, for use by
+// LockContext, which fires those hooks itself once for the whole call
+// instead of once per poll.
+func (rl *RowLock) tryLock(row Row) bool {
+	return rl.getLocker(row).(TryLocker).TryLock()
+}
+
+// LockContext locks a row, blocking until it is acquired or ctx is done.
+//
+// If ctx is done before the lock is acquired, LockContext returns ctx.Err()
+// and the row remains unlocked. OnWaitStart fires once per call, and OnLock
+// fires once when the lock is acquired (not when ctx ends the wait), covering
+// the whole call rather than each individual poll of the backoff loop.
+func (rl *RowLock) LockContext(ctx context.Context, row Row) error {
+	if rl.opts.OnWaitStart != nil {
+		rl.opts.OnWaitStart(row)
+	}
+	start := time.Now()
+	err := lockContext(ctx, func() bool {
+		return rl.tryLock(row)
+	})
+	if err == nil && rl.opts.OnLock != nil {
+		rl.opts.OnLock(row, time.Since(start))
+	}
+	return err
 }
 
 // getLocker returns the lock for the given row.
@@ -59,6 +138,8 @@ func (rl *RowLock) getLocker(row Row) sync.Locker {
 	locker, loaded := rl.locks.LoadOrStore(row, newLocker)
 	if loaded {
 		rl.lockerPool.Put(newLocker)
+	} else if rl.opts.OnLockerCreated != nil {
+		rl.opts.OnLockerCreated(row)
 	}
 	return locker.(sync.Locker)
 }
@@ -0,0 +1,138 @@
+package rowlock
+
+import (
+	"context"
+	"sync"
+)
+
+// NewRWLocker defines a type of function that can be used to create a new RWLocker.
+type NewRWLocker func() RWLocker
+
+// RWLocker is a locker that supports both exclusive and shared locking,
+// mirroring sync.RWMutex.
+type RWLocker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+	RLocker() sync.Locker
+}
+
+// RWMutexNewLocker is a NewRWLocker using sync.RWMutex.
+func RWMutexNewLocker() RWLocker {
+	return new(sync.RWMutex)
+}
+
+// RowRWLock defines a set of reader/writer locks.
+//
+// It behaves like RowLock, except that each row's locker additionally
+// supports shared (read) locking, so callers can allow concurrent readers
+// of the same row while still serializing writers.
+type RowRWLock struct {
+	locks      sync.Map
+	lockerPool sync.Pool
+}
+
+// NewRowRWLock creates a new RowRWLock with the given NewRWLocker.
+func NewRowRWLock(f NewRWLocker) *RowRWLock {
+	return &RowRWLock{
+		lockerPool: sync.Pool{
+			New: func() interface{} {
+				l := f()
+				if _, ok := l.(TryRWLocker); ok {
+					return l
+				}
+				return newSyncTryRWLocker(l)
+			},
+		},
+	}
+}
+
+// Lock acquires the exclusive (write) lock on a row.
+//
+// If this is a new row,
+// a new locker will be created using the NewRWLocker specified in NewRowRWLock.
+func (rl *RowRWLock) Lock(row Row) {
+	rl.getLocker(row).Lock()
+}
+
+// Unlock releases the exclusive (write) lock on a row.
+func (rl *RowRWLock) Unlock(row Row) {
+	rl.getLocker(row).Unlock()
+}
+
+// RLock acquires a shared (read) lock on a row.
+//
+// If this is a new row,
+// a new locker will be created using the NewRWLocker specified in NewRowRWLock.
+func (rl *RowRWLock) RLock(row Row) {
+	rl.getLocker(row).RLock()
+}
+
+// RUnlock releases a shared (read) lock on a row.
+func (rl *RowRWLock) RUnlock(row Row) {
+	rl.getLocker(row).RUnlock()
+}
+
+// RLocker returns a sync.Locker interface that implements the Lock and Unlock
+// methods by calling RLock and RUnlock on the given row, mirroring
+// sync.RWMutex.RLocker.
+func (rl *RowRWLock) RLocker(row Row) sync.Locker {
+	return rl.getLocker(row).RLocker()
+}
+
+// TryLock tries to acquire the exclusive (write) lock on a row without
+// blocking.
+//
+// It reports whether the lock was acquired.
+//
+// If this is a new row,
+// a new locker will be created using the NewRWLocker specified in NewRowRWLock.
+func (rl *RowRWLock) TryLock(row Row) bool {
+	return rl.getLocker(row).(TryRWLocker).TryLock()
+}
+
+// TryRLock tries to acquire a shared (read) lock on a row without blocking.
+//
+// It reports whether the lock was acquired.
+//
+// If this is a new row,
+// a new locker will be created using the NewRWLocker specified in NewRowRWLock.
+func (rl *RowRWLock) TryRLock(row Row) bool {
+	return rl.getLocker(row).(TryRWLocker).TryRLock()
+}
+
+// LockContext acquires the exclusive (write) lock on a row, blocking until it
+// is acquired or ctx is done.
+//
+// If ctx is done before the lock is acquired, LockContext returns ctx.Err()
+// and the row remains unlocked.
+func (rl *RowRWLock) LockContext(ctx context.Context, row Row) error {
+	return lockContext(ctx, func() bool {
+		return rl.TryLock(row)
+	})
+}
+
+// RLockContext acquires a shared (read) lock on a row, blocking until it is
+// acquired or ctx is done.
+//
+// If ctx is done before the lock is acquired, RLockContext returns ctx.Err()
+// and the row remains unlocked.
+func (rl *RowRWLock) RLockContext(ctx context.Context, row Row) error {
+	return lockContext(ctx, func() bool {
+		return rl.TryRLock(row)
+	})
+}
+
+// getLocker returns the RWLocker for the given row.
+//
+// If this is a new row,
+// a new locker will be created using the NewRWLocker specified in NewRowRWLock.
+func (rl *RowRWLock) getLocker(row Row) RWLocker {
+	newLocker := rl.lockerPool.Get()
+	locker, loaded := rl.locks.LoadOrStore(row, newLocker)
+	if loaded {
+		rl.lockerPool.Put(newLocker)
+	}
+	return locker.(RWLocker)
+}
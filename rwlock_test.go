@@ -0,0 +1,110 @@
+package rowlock
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// plainRWMutex is an RWLocker that does not implement TryLock/TryRLock, so
+// that RowRWLock falls back to syncTryRWLocker instead of using
+// *sync.RWMutex's native support.
+type plainRWMutex struct {
+	mu sync.RWMutex
+}
+
+func (m *plainRWMutex) Lock()   { m.mu.Lock() }
+func (m *plainRWMutex) Unlock() { m.mu.Unlock() }
+func (m *plainRWMutex) RLock()  { m.mu.RLock() }
+func (m *plainRWMutex) RUnlock() {
+	m.mu.RUnlock()
+}
+func (m *plainRWMutex) RLocker() sync.Locker { return m.mu.RLocker() }
+
+func newPlainRWMutex() RWLocker { return &plainRWMutex{} }
+
+func TestRowRWLockTryLockFallbackDoesNotBlockOnReader(t *testing.T) {
+	rl := NewRowRWLock(newPlainRWMutex)
+	rl.RLock("row")
+	defer rl.RUnlock("row")
+
+	done := make(chan bool, 1)
+	go func() { done <- rl.TryLock("row") }()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("TryLock succeeded while a reader held the row")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("TryLock blocked instead of returning immediately")
+	}
+}
+
+func TestRowRWLockTryRLockFallbackDoesNotBlockOnWriter(t *testing.T) {
+	rl := NewRowRWLock(newPlainRWMutex)
+	rl.Lock("row")
+	defer rl.Unlock("row")
+
+	done := make(chan bool, 1)
+	go func() { done <- rl.TryRLock("row") }()
+
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("TryRLock succeeded while a writer held the row")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("TryRLock blocked instead of returning immediately")
+	}
+}
+
+func TestRowRWLockTryLockFallbackSucceedsWhenFree(t *testing.T) {
+	rl := NewRowRWLock(newPlainRWMutex)
+
+	if !rl.TryLock("row") {
+		t.Fatal("TryLock failed on an uncontended row")
+	}
+	rl.Unlock("row")
+
+	if !rl.TryRLock("row") {
+		t.Fatal("TryRLock failed on an uncontended row")
+	}
+	rl.RUnlock("row")
+}
+
+func TestRowRWLockTryRLockFallbackAllowsConcurrentReaders(t *testing.T) {
+	rl := NewRowRWLock(newPlainRWMutex)
+
+	if !rl.TryRLock("row") {
+		t.Fatal("first TryRLock failed on an uncontended row")
+	}
+	defer rl.RUnlock("row")
+
+	if !rl.TryRLock("row") {
+		t.Fatal("second concurrent TryRLock failed")
+	}
+	rl.RUnlock("row")
+}
+
+func TestRowRWLockFallbackUnderRace(t *testing.T) {
+	rl := NewRowRWLock(newPlainRWMutex)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if j%2 == 0 {
+					rl.Lock("row")
+					rl.Unlock("row")
+				} else {
+					rl.RLock("row")
+					rl.RUnlock("row")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
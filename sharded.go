@@ -0,0 +1,111 @@
+package rowlock
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// RowLocker is the common interface implemented by RowLock and
+// ShardedRowLock, so callers can swap between the two locking strategies
+// without changing call sites.
+type RowLocker interface {
+	Lock(row Row)
+	Unlock(row Row)
+}
+
+// Hasher maps a Row to a hash, used by ShardedRowLock to pick a shard.
+type Hasher func(row Row) uint64
+
+// KeyForString returns a Hasher for rows that are strings.
+//
+// It panics if a row passed to ShardedRowLock is not a string.
+func KeyForString() Hasher {
+	return func(row Row) uint64 {
+		h := fnv.New64a()
+		h.Write([]byte(row.(string)))
+		return h.Sum64()
+	}
+}
+
+// KeyForBytes returns a Hasher for rows that are []byte.
+//
+// It panics if a row passed to ShardedRowLock is not a []byte.
+func KeyForBytes() Hasher {
+	return func(row Row) uint64 {
+		h := fnv.New64a()
+		h.Write(row.([]byte))
+		return h.Sum64()
+	}
+}
+
+// defaultHasher hashes the row's default string formatting, so that
+// ShardedRowLock works out of the box with any Row without requiring callers
+// to supply a Hasher.
+func defaultHasher(row Row) uint64 {
+	h := fnv.New64a()
+	fmt.Fprint(h, row)
+	return h.Sum64()
+}
+
+// ShardedRowLockOption configures a ShardedRowLock created by
+// NewShardedRowLock.
+type ShardedRowLockOption func(*ShardedRowLock)
+
+// WithHasher sets the Hasher used to map rows to shards.
+//
+// The default hasher formats the row with fmt and hashes the result, which
+// works for any Row but is slower than hashing a known type directly; use
+// KeyForString or KeyForBytes (or a custom Hasher) when the row type is
+// known.
+func WithHasher(h Hasher) ShardedRowLockOption {
+	return func(s *ShardedRowLock) {
+		s.hash = h
+	}
+}
+
+// ShardedRowLock defines a fixed-size set of locks.
+//
+// Unlike RowLock, which stores one locker per distinct row forever,
+// ShardedRowLock allocates a fixed number of lockers up front and dispatches
+// each row to one of them by hashing. This bounds memory at the cost of
+// occasional false contention between unrelated rows that hash to the same
+// shard.
+type ShardedRowLock struct {
+	shards []sync.Locker
+	hash   Hasher
+}
+
+// NewShardedRowLock creates a new ShardedRowLock with the given number of
+// shards, each backed by a locker created with f.
+func NewShardedRowLock(shards int, f NewLocker, opts ...ShardedRowLockOption) *ShardedRowLock {
+	if shards <= 0 {
+		panic("rowlock: shards must be positive")
+	}
+	s := &ShardedRowLock{
+		shards: make([]sync.Locker, shards),
+		hash:   defaultHasher,
+	}
+	for i := range s.shards {
+		s.shards[i] = f()
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Lock locks the shard that row hashes to.
+func (s *ShardedRowLock) Lock(row Row) {
+	s.shardFor(row).Lock()
+}
+
+// Unlock unlocks the shard that row hashes to.
+func (s *ShardedRowLock) Unlock(row Row) {
+	s.shardFor(row).Unlock()
+}
+
+// shardFor returns the locker for the shard that row hashes to.
+func (s *ShardedRowLock) shardFor(row Row) sync.Locker {
+	return s.shards[s.hash(row)%uint64(len(s.shards))]
+}
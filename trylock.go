@@ -0,0 +1,185 @@
+package rowlock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// initialLockBackoff and maxLockBackoff bound the exponential backoff used by
+// LockContext while it polls a contended row via TryLock.
+const (
+	initialLockBackoff = time.Millisecond
+	maxLockBackoff     = 50 * time.Millisecond
+)
+
+// TryLocker is a locker that additionally supports non-blocking acquisition,
+// mirroring sync.Mutex.TryLock.
+type TryLocker interface {
+	sync.Locker
+	TryLock() bool
+}
+
+// TryRWLocker is a RWLocker that additionally supports non-blocking
+// acquisition of both the exclusive and shared lock, mirroring
+// sync.RWMutex.TryLock and sync.RWMutex.TryRLock.
+type TryRWLocker interface {
+	RWLocker
+	TryLock() bool
+	TryRLock() bool
+}
+
+// chanTryLocker adapts a sync.Locker that doesn't natively support TryLock
+// into a TryLocker, using a buffered channel as a binary semaphore guarding
+// the underlying locker.
+type chanTryLocker struct {
+	sync.Locker
+	sem chan struct{}
+}
+
+func newChanTryLocker(l sync.Locker) *chanTryLocker {
+	return &chanTryLocker{Locker: l, sem: make(chan struct{}, 1)}
+}
+
+func (c *chanTryLocker) Lock() {
+	c.sem <- struct{}{}
+	c.Locker.Lock()
+}
+
+func (c *chanTryLocker) Unlock() {
+	c.Locker.Unlock()
+	<-c.sem
+}
+
+func (c *chanTryLocker) TryLock() bool {
+	select {
+	case c.sem <- struct{}{}:
+	default:
+		return false
+	}
+	c.Locker.Lock()
+	return true
+}
+
+// syncTryRWLocker adapts an RWLocker that doesn't natively support TryLock
+// and TryRLock into a TryRWLocker.
+//
+// It tracks who currently holds the lock (a writer, or some number of
+// readers) itself, under its own mutex, so that a Try call can tell
+// immediately whether acquiring would block instead of having to start the
+// underlying acquisition and find out. Because every Lock/Unlock/RLock/
+// RUnlock call is funneled through that same bookkeeping, by the time it
+// calls through to the underlying RWLocker the call is guaranteed to
+// succeed without blocking, so the underlying locker is used purely for its
+// side effects (e.g. a custom RWLocker backed by something other than plain
+// memory).
+type syncTryRWLocker struct {
+	RWLocker
+	mu      sync.Mutex
+	cond    *sync.Cond
+	readers int
+	writer  bool
+}
+
+func newSyncTryRWLocker(l RWLocker) *syncTryRWLocker {
+	c := &syncTryRWLocker{RWLocker: l}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *syncTryRWLocker) Lock() {
+	c.mu.Lock()
+	for c.writer || c.readers > 0 {
+		c.cond.Wait()
+	}
+	c.writer = true
+	c.mu.Unlock()
+	c.RWLocker.Lock()
+}
+
+func (c *syncTryRWLocker) Unlock() {
+	c.RWLocker.Unlock()
+	c.mu.Lock()
+	c.writer = false
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+func (c *syncTryRWLocker) TryLock() bool {
+	c.mu.Lock()
+	if c.writer || c.readers > 0 {
+		c.mu.Unlock()
+		return false
+	}
+	c.writer = true
+	c.mu.Unlock()
+	c.RWLocker.Lock()
+	return true
+}
+
+func (c *syncTryRWLocker) RLock() {
+	c.mu.Lock()
+	for c.writer {
+		c.cond.Wait()
+	}
+	c.readers++
+	c.mu.Unlock()
+	c.RWLocker.RLock()
+}
+
+func (c *syncTryRWLocker) RUnlock() {
+	c.RWLocker.RUnlock()
+	c.mu.Lock()
+	c.readers--
+	if c.readers == 0 {
+		c.cond.Broadcast()
+	}
+	c.mu.Unlock()
+}
+
+func (c *syncTryRWLocker) TryRLock() bool {
+	c.mu.Lock()
+	if c.writer {
+		c.mu.Unlock()
+		return false
+	}
+	c.readers++
+	c.mu.Unlock()
+	c.RWLocker.RLock()
+	return true
+}
+
+// RLocker returns a sync.Locker backed by c's own RLock/RUnlock, not the
+// underlying RWLocker's, so that acquisitions through it stay visible to c's
+// bookkeeping.
+func (c *syncTryRWLocker) RLocker() sync.Locker {
+	return (*syncTryRWRLocker)(c)
+}
+
+type syncTryRWRLocker syncTryRWLocker
+
+func (r *syncTryRWRLocker) Lock()   { (*syncTryRWLocker)(r).RLock() }
+func (r *syncTryRWRLocker) Unlock() { (*syncTryRWLocker)(r).RUnlock() }
+
+// lockContext polls tryLock with exponential backoff until it succeeds or ctx
+// is done. It is shared by RowLock.LockContext and RowRWLock's context-aware
+// lock methods.
+func lockContext(ctx context.Context, tryLock func() bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	backoff := initialLockBackoff
+	for {
+		if tryLock() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxLockBackoff {
+			backoff = maxLockBackoff
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package rowlock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// plainMutex is a sync.Locker that does not implement TryLocker, so RowLock
+// falls back to chanTryLocker instead of using *sync.Mutex's native
+// TryLock.
+type plainMutex struct {
+	mu sync.Mutex
+}
+
+func (m *plainMutex) Lock()   { m.mu.Lock() }
+func (m *plainMutex) Unlock() { m.mu.Unlock() }
+
+func newPlainMutex() sync.Locker { return &plainMutex{} }
+
+func TestRowLockChanTryLockerFallbackNonBlocking(t *testing.T) {
+	rl := NewRowLock(newPlainMutex)
+
+	if !rl.TryLock("row") {
+		t.Fatal("TryLock failed on an uncontended row")
+	}
+
+	done := make(chan bool, 1)
+	go func() { done <- rl.TryLock("row") }()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Fatal("TryLock succeeded while the row was already locked")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("TryLock blocked instead of returning immediately")
+	}
+
+	rl.Unlock("row")
+
+	if !rl.TryLock("row") {
+		t.Fatal("TryLock failed on a row that was just unlocked")
+	}
+	rl.Unlock("row")
+}
+
+func TestRowLockChanTryLockerMutualExclusion(t *testing.T) {
+	rl := NewRowLock(newPlainMutex)
+
+	var counter int
+	var wg sync.WaitGroup
+	const goroutines = 16
+	const iterations = 200
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				rl.Lock("row")
+				counter++
+				rl.Unlock("row")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counter != goroutines*iterations {
+		t.Fatalf("counter = %d, want %d (lost updates indicate a broken fallback lock)", counter, goroutines*iterations)
+	}
+}
+
+func TestRowLockLockContextSucceedsAfterContention(t *testing.T) {
+	rl := NewRowLock(newPlainMutex)
+
+	rl.Lock("row")
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		rl.Unlock("row")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rl.LockContext(ctx, "row"); err != nil {
+		t.Fatalf("LockContext returned error: %v", err)
+	}
+	rl.Unlock("row")
+}
+
+func TestRowLockLockContextCancellation(t *testing.T) {
+	rl := NewRowLock(newPlainMutex)
+
+	rl.Lock("row")
+	defer rl.Unlock("row")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := rl.LockContext(ctx, "row")
+	if err != context.DeadlineExceeded {
+		t.Fatalf("LockContext returned %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("LockContext took %v to return after its deadline", elapsed)
+	}
+}
+
+func TestRowRWLockLockContextSucceedsAfterContention(t *testing.T) {
+	rl := NewRowRWLock(newPlainRWMutex)
+
+	rl.Lock("row")
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		rl.Unlock("row")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rl.LockContext(ctx, "row"); err != nil {
+		t.Fatalf("LockContext returned error: %v", err)
+	}
+	rl.Unlock("row")
+}
+
+func TestRowRWLockRLockContextSucceedsAfterContention(t *testing.T) {
+	rl := NewRowRWLock(newPlainRWMutex)
+
+	rl.Lock("row")
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		rl.Unlock("row")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rl.RLockContext(ctx, "row"); err != nil {
+		t.Fatalf("RLockContext returned error: %v", err)
+	}
+	rl.RUnlock("row")
+}
+
+func TestRowRWLockLockContextCancellation(t *testing.T) {
+	rl := NewRowRWLock(newPlainRWMutex)
+
+	rl.Lock("row")
+	defer rl.Unlock("row")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	if err := rl.LockContext(ctx, "row"); err != context.DeadlineExceeded {
+		t.Fatalf("LockContext returned %v, want context.DeadlineExceeded", err)
+	}
+}